@@ -0,0 +1,139 @@
+package main
+
+import "testing"
+
+// TestAnalyzeNesting builds a graph with more than two levels of loop
+// nesting (buildGraphN(1, 1, 1) gives a mid-level loop around a
+// BaseLoop around its two Diamond loops) and
+// checks that Analyze's derived fields respect loop nesting rather than
+// treating every loop as flat.
+func TestAnalyzeNesting(t *testing.T) {
+	g := buildGraphN(1, 1, 1)
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+	lsg.CalculateNesting()
+
+	var outer, inner int
+	for _, l := range lsg.Loop {
+		if l.IsRoot {
+			continue
+		}
+		if len(l.Child) > 0 {
+			outer++
+		} else {
+			inner++
+		}
+	}
+	if outer == 0 || inner == 0 {
+		t.Fatalf("expected both outer and inner loops in a 3-level nest, got outer=%d inner=%d", outer, inner)
+	}
+
+	lsg.Analyze(g, func(*Block) bool { return false })
+	for _, l := range lsg.Loop {
+		if l.IsRoot {
+			continue
+		}
+		if got, want := l.IsInner, len(l.Child) == 0; got != want {
+			t.Errorf("loop-%d: IsInner = %v, want %v (len(Child) = %d)", l.Counter, got, want, len(l.Child))
+		}
+		if l.ContainsUnavoidableCall {
+			t.Errorf("loop-%d: ContainsUnavoidableCall = true with an isCall that always returns false", l.Counter)
+		}
+		// A loop's Exits must leave the loop entirely, including any
+		// nested child loops' blocks -- a nested loop's header is not
+		// an exit of its parent.
+		all := allBlocks(l)
+		in := make(map[*Block]bool, len(all))
+		for _, b := range all {
+			in[b] = true
+		}
+		for _, exit := range l.Exits {
+			if in[exit] {
+				t.Errorf("loop-%d: Exits contains %v, which is still inside the loop (including nested children)", l.Counter, exit)
+			}
+		}
+		if int(l.NBlocks) != len(l.Block) {
+			t.Errorf("loop-%d: NBlocks = %d, want %d (len(Block), excluding nested children)", l.Counter, l.NBlocks, len(l.Block))
+		}
+	}
+
+	// With an isCall that always returns true, no loop can avoid a
+	// call on the way back to its header, so every loop must report
+	// ContainsUnavoidableCall.
+	lsg.Analyze(g, func(*Block) bool { return true })
+	for _, l := range lsg.Loop {
+		if l.IsRoot {
+			continue
+		}
+		if !l.ContainsUnavoidableCall {
+			t.Errorf("loop-%d: ContainsUnavoidableCall = false with an isCall that always returns true", l.Counter)
+		}
+	}
+}
+
+// TestFindLoopsNoDuplicateChildren covers a header reached by two
+// back-edges whose sources both collapse (via union-find) to the same
+// nested loop's representative: the pool built at Step D held that
+// representative twice, so the outer loop ended up with the same
+// nested loop appended to Child twice.
+func TestFindLoopsNoDuplicateChildren(t *testing.T) {
+	g := new(CFG)
+	entry := g.NewBlock()
+	a := g.NewBlock() // outer header
+	b := g.NewBlock()
+	c := g.NewBlock()
+	exit := g.NewBlock()
+	g.Connect(entry, a)
+	g.Connect(a, b)
+	g.Connect(b, c)
+	g.Connect(c, b) // inner back-edge, inner header b
+	g.Connect(b, a) // outer back-edge #1
+	g.Connect(c, a) // outer back-edge #2, same union-find representative as #1
+	g.Connect(c, exit)
+
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+
+	for _, l := range lsg.Loop {
+		seen := map[*Loop]bool{}
+		for _, child := range l.Child {
+			if seen[child] {
+				t.Fatalf("loop-%d: Child contains loop-%d more than once: %v", l.Counter, child.Counter, l.Child)
+			}
+			seen[child] = true
+		}
+	}
+}
+
+// TestAnalyzeSelfLoopCall covers a single-block self-loop (header ->
+// header) whose header is flagged as a call: every iteration passes
+// through header, so ContainsUnavoidableCall must be true even though
+// the "out == header" arrival back at the header would otherwise look
+// like a call-free path.
+func TestAnalyzeSelfLoopCall(t *testing.T) {
+	g := new(CFG)
+	entry := g.NewBlock()
+	header := g.NewBlock()
+	g.Connect(entry, header)
+	g.Connect(header, header) // self-loop back-edge
+
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+	lsg.Analyze(g, func(b *Block) bool { return b == header })
+
+	var l *Loop
+	for _, loop := range lsg.Loop {
+		if !loop.IsRoot && loop.Head == header {
+			l = loop
+		}
+	}
+	if l == nil {
+		t.Fatalf("no loop found headed by %v", header)
+	}
+	if !l.ContainsUnavoidableCall {
+		t.Errorf("ContainsUnavoidableCall = false for a self-loop whose sole block is call-flagged, want true")
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import "sort"
+
+// Dominator tree computation, used as an alternative entry point into
+// loop finding. The construction follows the classic iterative
+// dataflow algorithm (Cooper, Harvey, Kennedy, "A Simple, Fast
+// Dominance Algorithm") rather than Lengauer-Tarjan, since our graphs
+// are small enough that the O(n^2) worst case never shows up in
+// practice and the code is a lot easier to follow.
+
+type Dominators struct {
+	Idom []*Block // immediate dominator of block i, indexed by Block.Name
+	tree SparseTree
+
+	// dfs is ancestry in the depth-first spanning tree Compute walks
+	// to number blocks, as opposed to tree's dominator-tree ancestry.
+	// The two coincide for reducible graphs but not in general; back-
+	// edges are classified against dfs, irreducibility against tree.
+	dfs SparseTree
+}
+
+// Compute fills in the immediate-dominator tree for g, assuming
+// g.Block[0] is the entry block from which every other block is
+// reachable.
+func (d *Dominators) Compute(g *CFG) {
+	size := len(g.Block)
+	if size == 0 {
+		return
+	}
+
+	postorder := make([]*Block, 0, size)
+	dfsParent := make([]*Block, size)
+	seen := make([]bool, size)
+	var visit func(b, parent *Block)
+	visit = func(b, parent *Block) {
+		seen[b.Name] = true
+		dfsParent[b.Name] = parent
+		for _, out := range b.Out {
+			if !seen[out.Name] {
+				visit(out, b)
+			}
+		}
+		postorder = append(postorder, b)
+	}
+	visit(g.Block[0], nil)
+	d.dfs.build(g, dfsParent)
+
+	rpostorder := make([]*Block, len(postorder))
+	for i, b := range postorder {
+		rpostorder[len(postorder)-1-i] = b
+	}
+
+	rpoNumber := make([]int, size)
+	for i := range rpoNumber {
+		rpoNumber[i] = -1
+	}
+	for i, b := range rpostorder {
+		rpoNumber[b.Name] = i
+	}
+
+	idom := make([]*Block, size)
+	idom[g.Block[0].Name] = g.Block[0]
+
+	changed := true
+	for changed {
+		changed = false
+		for _, b := range rpostorder {
+			if b == g.Block[0] {
+				continue
+			}
+			var newIdom *Block
+			for _, p := range b.In {
+				if idom[p.Name] == nil {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = p
+					continue
+				}
+				newIdom = intersect(idom, rpoNumber, newIdom, p)
+			}
+			if newIdom != nil && idom[b.Name] != newIdom {
+				idom[b.Name] = newIdom
+				changed = true
+			}
+		}
+	}
+	idom[g.Block[0].Name] = nil // the entry block has no dominator
+
+	d.Idom = idom
+	d.tree.build(g, idom)
+}
+
+func intersect(idom []*Block, rpoNumber []int, a, b *Block) *Block {
+	for a != b {
+		for rpoNumber[a.Name] > rpoNumber[b.Name] {
+			a = idom[a.Name]
+		}
+		for rpoNumber[b.Name] > rpoNumber[a.Name] {
+			b = idom[b.Name]
+		}
+	}
+	return a
+}
+
+// IsAncestor reports whether a dominates b in the dominator tree
+// (equivalently, whether a is an ancestor of b), in O(1) time.
+func (d *Dominators) IsAncestor(a, b *Block) bool {
+	return d.tree.IsAncestor(a, b)
+}
+
+// SparseTree answers ancestor queries on a rooted tree in O(1) via a
+// DFS pre/post numbering, the same trick used by the SparseTree type
+// in the Go compiler's SSA package (cmd/compile/internal/ssa,
+// likelyadjust.go): a node x is an ancestor of y iff x.entry <= y.entry
+// and y.exit <= x.exit.
+type SparseTree struct {
+	entry []int32
+	exit  []int32
+}
+
+func (t *SparseTree) build(g *CFG, idom []*Block) {
+	size := len(g.Block)
+	children := make([][]*Block, size)
+	var root *Block
+	for _, b := range g.Block {
+		if idom[b.Name] == nil {
+			root = b
+			continue
+		}
+		p := idom[b.Name].Name
+		children[p] = append(children[p], b)
+	}
+
+	t.entry = make([]int32, size)
+	t.exit = make([]int32, size)
+	clock := int32(0)
+	var walk func(b *Block)
+	walk = func(b *Block) {
+		clock++
+		t.entry[b.Name] = clock
+		for _, c := range children[b.Name] {
+			walk(c)
+		}
+		clock++
+		t.exit[b.Name] = clock
+	}
+	if root != nil {
+		walk(root)
+	}
+}
+
+func (t *SparseTree) IsAncestor(a, b *Block) bool {
+	return t.entry[a.Name] <= t.entry[b.Name] && t.exit[b.Name] <= t.exit[a.Name]
+}
+
+// DominatorLoopFinder finds natural loops using the dominator tree
+// instead of the Tarjan-style union-find pass in LoopFinder. A natural
+// loop is discovered for every back-edge u->v where v dominates u; the
+// loop body is the set of blocks that can reach u without passing
+// through v. Edges that are back-edges (target is an ancestor of the
+// source in the DFS spanning tree) but whose target does not dominate
+// the source indicate an
+// irreducible region, so they are reported separately rather than
+// turned into loops.
+type DominatorLoopFinder struct {
+	Dominators
+
+	// Irreducible holds back-edges u->v found in the CFG for which v
+	// does not dominate u, i.e. edges that cannot be explained by a
+	// natural loop.
+	Irreducible []Edge
+}
+
+func (f *DominatorLoopFinder) FindLoops(g *CFG, lsg *LoopGraph) {
+	f.Compute(g)
+	f.Irreducible = f.Irreducible[:0]
+
+	// rawBody[h] holds every block that can reach, without leaving
+	// h's dominator subtree, a latch of some back-edge targeting h --
+	// the full natural-loop body before nested loops are carved out
+	// of it. Multiple back-edges to the same header merge into one
+	// rawBody entry.
+	var headers []*Block
+	rawBody := map[*Block]map[*Block]bool{}
+	addRaw := func(header *Block, body []*Block) {
+		set := rawBody[header]
+		if set == nil {
+			set = map[*Block]bool{}
+			rawBody[header] = set
+			headers = append(headers, header)
+		}
+		for _, b := range body {
+			set[b] = true
+		}
+	}
+
+	for _, b := range g.Block {
+		for _, succ := range b.Out {
+			if b == succ {
+				addRaw(succ, []*Block{b})
+				continue
+			}
+			if !f.dfs.IsAncestor(succ, b) {
+				continue // forward or cross edge, not a back-edge
+			}
+			if !f.IsAncestor(succ, b) {
+				// A back-edge whose target does not dominate its
+				// source can't be explained by a natural loop.
+				f.Irreducible = append(f.Irreducible, Edge{b.Name, succ.Name})
+				continue
+			}
+			body := f.natural(g, succ, b)
+			if body == nil {
+				f.Irreducible = append(f.Irreducible, Edge{b.Name, succ.Name})
+				continue
+			}
+			addRaw(succ, body)
+		}
+	}
+	if len(headers) == 0 {
+		return
+	}
+
+	// Process headers from the smallest natural body to the largest,
+	// so a nested loop claims its blocks before its enclosing loop
+	// computes what's left over. This gives the outer/inner linking
+	// (Parent/Child) the request asked for, and keeps each block
+	// owned by exactly its innermost loop, matching the invariant
+	// LoopFinder.FindLoops maintains via its own Parent/Child links.
+	sort.Slice(headers, func(i, j int) bool {
+		if n, m := len(rawBody[headers[i]]), len(rawBody[headers[j]]); n != m {
+			return n < m
+		}
+		return headers[i].Name < headers[j].Name
+	})
+
+	loops := make(map[*Block]*Loop, len(headers))
+	claimed := map[*Block]bool{}
+	for _, header := range headers {
+		f.addLoop(lsg, loops, claimed, header, rawBody[header])
+	}
+	// The immediate enclosing loop of header, if any, is the nearest
+	// dominator of header that is itself a loop header whose body
+	// contains header: for a reducible graph, a loop's header always
+	// dominates the header of any loop nested inside it, so walking
+	// the dominator-tree ancestor chain (instead of scanning every
+	// other header) finds it in O(depth) rather than O(len(headers)).
+	for _, header := range headers {
+		l := loops[header]
+		for anc := f.Idom[header.Name]; anc != nil; anc = f.Idom[anc.Name] {
+			if rawBody[anc][header] {
+				l.Parent = loops[anc]
+				loops[anc].Child = append(loops[anc].Child, l)
+				break
+			}
+		}
+	}
+}
+
+// natural collects the body of the natural loop headed by header with
+// back-edge from latch, by walking predecessors backward from latch
+// until header is reached, staying inside header's dominator subtree.
+// It returns nil if some predecessor reached outside that subtree,
+// which signals an irreducible region instead.
+func (f *DominatorLoopFinder) natural(g *CFG, header, latch *Block) []*Block {
+	body := []*Block{header}
+	seen := map[int]bool{header.Name: true, latch.Name: true}
+	work := []*Block{latch}
+	body = append(body, latch)
+	for len(work) > 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, p := range b.In {
+			if seen[p.Name] {
+				continue
+			}
+			if !f.IsAncestor(header, p) {
+				return nil
+			}
+			seen[p.Name] = true
+			body = append(body, p)
+			work = append(work, p)
+		}
+	}
+	return body
+}
+
+// addLoop creates the Loop for header's natural loop, whose body is
+// rawBody minus whatever a smaller, already-processed nested loop has
+// already claimed -- so a block ends up in exactly its innermost
+// loop's Block, with nesting expressed through Parent/Child instead.
+func (f *DominatorLoopFinder) addLoop(lsg *LoopGraph, loops map[*Block]*Loop, claimed map[*Block]bool, header *Block, rawBody map[*Block]bool) {
+	l := lsg.NewLoop(len(rawBody))
+	l.Head = header
+	l.IsReducible = true
+	loops[header] = l
+
+	blocks := make([]*Block, 0, len(rawBody))
+	for b := range rawBody {
+		blocks = append(blocks, b)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i].Name < blocks[j].Name })
+	for _, b := range blocks {
+		if !claimed[b] {
+			l.Block = append(l.Block, b)
+		}
+		claimed[b] = true
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteDOT emits g in Graphviz DOT format, with no loop information.
+// Use (*LoopGraph).WriteDOT for a version that highlights loop
+// headers, back-edges and nesting.
+func (g *CFG) WriteDOT(w io.Writer) {
+	fmt.Fprintln(w, "digraph CFG {")
+	for _, b := range g.Block {
+		fmt.Fprintf(w, "\t%s;\n", dotName(b))
+	}
+	for _, e := range g.Edge {
+		fmt.Fprintf(w, "\t%s -> %s;\n", dotName(g.Block[e.Src]), dotName(g.Block[e.Dst]))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// WriteDOT emits g's CFG in Graphviz DOT format with loop structure
+// overlaid: loop headers are filled, back-edges are dashed, and each
+// loop is drawn as a subgraph cluster nested according to lsg's loop
+// tree, outlined in red for an irreducible loop and black otherwise. This is
+// meant as a debugging aid for graphs too large for Dump to be
+// readable, such as buildGraph()'s ~70k blocks.
+func (g *LoopGraph) WriteDOT(w io.Writer, cfg *CFG) {
+	backEdge := make(map[Edge]bool)
+	for _, l := range g.Loop {
+		if l.IsRoot || l.Head == nil {
+			continue
+		}
+		for _, b := range allBlocks(l) {
+			if hasEdge(b, l.Head) {
+				backEdge[Edge{b.Name, l.Head.Name}] = true
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "digraph LoopGraph {")
+	fmt.Fprintln(w, "\tcompound=true;")
+	emitted := map[*Block]bool{}
+	g.writeDOTCluster(w, &g.Root, emitted)
+
+	// Any block not claimed by a cluster (e.g. lsg came from a partial
+	// run) is still emitted so edges below resolve.
+	for _, b := range cfg.Block {
+		if !emitted[b] {
+			fmt.Fprintf(w, "\t%s;\n", dotName(b))
+		}
+	}
+
+	for _, e := range cfg.Edge {
+		src, dst := cfg.Block[e.Src], cfg.Block[e.Dst]
+		attrs := ""
+		if backEdge[e] {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(w, "\t%s -> %s%s;\n", dotName(src), dotName(dst), attrs)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+func (g *LoopGraph) writeDOTCluster(w io.Writer, l *Loop, emitted map[*Block]bool) {
+	if l != &g.Root {
+		fmt.Fprintf(w, "\tsubgraph cluster_%d {\n", l.Counter)
+		fmt.Fprintf(w, "\t\tlabel=%q;\n", fmt.Sprintf("loop-%d (nest %d)", l.Counter, l.Nesting))
+		if !l.IsReducible {
+			fmt.Fprintln(w, "\t\tcolor=red;")
+		} else {
+			fmt.Fprintln(w, "\t\tcolor=black;")
+		}
+		for _, b := range l.Block {
+			if emitted[b] {
+				continue
+			}
+			emitted[b] = true
+			attrs := ""
+			if b == l.Head {
+				attrs = " [style=filled]"
+			}
+			fmt.Fprintf(w, "\t\t%s%s;\n", dotName(b), attrs)
+		}
+	}
+	for _, child := range l.Child {
+		g.writeDOTCluster(w, child, emitted)
+	}
+	if l != &g.Root {
+		fmt.Fprintln(w, "\t}")
+	}
+}
+
+func dotName(b *Block) string {
+	return fmt.Sprintf("b%d", b.Name)
+}
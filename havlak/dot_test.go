@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	g := buildGraphN(2, 5, 3)
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+	lsg.CalculateNesting()
+
+	var buf bytes.Buffer
+	lsg.WriteDOT(&buf, g)
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "digraph LoopGraph {") {
+		t.Fatalf("output does not start with the expected digraph header: %q", out[:40])
+	}
+	if n := strings.Count(out, "subgraph cluster_"); n != len(lsg.Loop) {
+		t.Errorf("got %d subgraph clusters, want %d (one per loop)", n, len(lsg.Loop))
+	}
+	for _, b := range g.Block {
+		if !strings.Contains(out, dotName(b)+";") {
+			t.Fatalf("block %v missing from DOT output", b)
+		}
+	}
+}
+
+func TestCFGWriteDOT(t *testing.T) {
+	g := buildGraphN(1, 1, 1)
+	var buf bytes.Buffer
+	g.WriteDOT(&buf)
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "digraph CFG {") {
+		t.Fatalf("output does not start with the expected digraph header: %q", out[:40])
+	}
+	if n := strings.Count(out, "->"); n != len(g.Edge) {
+		t.Errorf("got %d edges in DOT output, want %d", n, len(g.Edge))
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := buildGraphN(2, 5, 3)
+
+	var buf bytes.Buffer
+	if err := g.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	g2, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadJSON: %v", err)
+	}
+	if len(g2.Block) != len(g.Block) {
+		t.Fatalf("got %d blocks after round-trip, want %d", len(g2.Block), len(g.Block))
+	}
+	if len(g2.Edge) != len(g.Edge) {
+		t.Fatalf("got %d edges after round-trip, want %d", len(g2.Edge), len(g.Edge))
+	}
+
+	var f1, f2 LoopFinder
+	lsg1, lsg2 := new(LoopGraph), new(LoopGraph)
+	f1.FindLoops(g, lsg1)
+	f2.FindLoops(g2, lsg2)
+	if len(lsg1.Loop) != len(lsg2.Loop) {
+		t.Errorf("loop count after round-trip = %d, want %d", len(lsg2.Loop), len(lsg1.Loop))
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"runtime/pprof"
+	"sync/atomic"
 )
 
 // Control flow graph, created once.
@@ -15,6 +16,11 @@ type Block struct {
 	Name int
 	In   []*Block
 	Out  []*Block
+
+	// Synthetic marks blocks inserted by a CFG transformation pass
+	// (for example InsertReschedChecks) rather than built by the
+	// original graph construction.
+	Synthetic bool
 }
 
 func (b *Block) String() string {
@@ -74,6 +80,14 @@ func (g *CFG) BaseLoop(from *Block) *Block {
 }
 
 func buildGraph() *CFG {
+	return buildGraphN(10, 100, 25)
+}
+
+// buildGraphN generalizes buildGraph over its three nesting
+// parameters (outer siblings, loops per sibling, BaseLoops per loop),
+// so callers such as the benchmarks in havlak_test.go can ask for
+// smaller or larger graphs than the fixed 10/100/25 buildGraph uses.
+func buildGraphN(outer, mid, inner int) *CFG {
 	g := new(CFG)
 
 	n0 := g.NewBlock()
@@ -81,14 +95,14 @@ func buildGraph() *CFG {
 	n2 := g.NewBlock()
 	g.Connect(n0, n2)
 
-	for i := 0; i < 10; i++ {
+	for i := 0; i < outer; i++ {
 		n := g.NewBlock()
 		g.Connect(n2, n)
 
-		for j := 0; j < 100; j++ {
+		for j := 0; j < mid; j++ {
 			top := n
 			n = g.Path(n)
-			for k := 0; k < 25; k++ {
+			for k := 0; k < inner; k++ {
 				n = g.BaseLoop(n)
 			}
 			bottom := g.Path(n)
@@ -118,9 +132,20 @@ type Loop struct {
 	Counter     int
 	Nesting     int
 	Depth       int
+
+	// Filled in by LoopGraph.Analyze.
+	Exits                   []*Block
+	NBlocks                 int32
+	IsInner                 bool
+	ContainsUnavoidableCall bool
 }
 
-var loopCounter = 0
+// loopCounter is package-level (rather than per-LoopGraph) so every
+// Loop ever created gets a unique Counter even across separate
+// LoopGraphs, including the independent ones FindLoopsParallel's
+// per-partition goroutines each run their own LoopFinder against; it
+// must therefore be updated atomically.
+var loopCounter atomic.Int64
 
 func (g *LoopGraph) Clear() {
 	g.Root.Child = g.Root.Child[:0]
@@ -143,8 +168,7 @@ func (g *LoopGraph) NewLoop(lcap int) *Loop {
 		return l
 	}
 
-	loopCounter++
-	l := &Loop{Counter: loopCounter}
+	l := &Loop{Counter: int(loopCounter.Add(1))}
 	g.Loop = append(g.Loop, l)
 	l.Block = make([]*Block, 0, lcap)
 	return l
@@ -174,6 +198,85 @@ func (g *LoopGraph) calculateNesting(l *Loop, depth int) {
 	}
 }
 
+// Analyze fills in the derived attributes Exits, NBlocks, IsInner and
+// ContainsUnavoidableCall on every loop in g, mirroring the facts the
+// Go compiler's SSA package computes on its own loop struct in
+// likelyadjust.go. It must be called after FindLoops (or after a
+// DominatorLoopFinder run) has populated g.
+func (g *LoopGraph) Analyze(cfg *CFG, isCall func(*Block) bool) {
+	all := make(map[*Loop][]*Block, len(g.Loop))
+	for _, l := range g.Loop {
+		all[l] = allBlocks(l)
+	}
+
+	for _, l := range g.Loop {
+		if l.IsRoot {
+			continue
+		}
+		l.IsInner = len(l.Child) == 0
+		l.NBlocks = int32(len(l.Block))
+		l.Exits = l.Exits[:0]
+
+		in := make(map[*Block]bool, len(all[l]))
+		for _, b := range all[l] {
+			in[b] = true
+		}
+		for _, b := range all[l] {
+			for _, out := range b.Out {
+				if !in[out] {
+					l.Exits = append(l.Exits, out)
+				}
+			}
+		}
+
+		l.ContainsUnavoidableCall = !canReachHeaderAvoidingCalls(l.Head, in, isCall)
+	}
+}
+
+// allBlocks returns every block contained in l, including those that
+// belong only to descendant loops.
+func allBlocks(l *Loop) []*Block {
+	blocks := append([]*Block(nil), l.Block...)
+	for _, child := range l.Child {
+		blocks = append(blocks, allBlocks(child)...)
+	}
+	return blocks
+}
+
+// canReachHeaderAvoidingCalls reports whether there is a path from one
+// of header's successors back to header, staying within the loop (as
+// given by the in set), that never passes through a block for which
+// isCall reports true. If no such path exists, every path through the
+// loop back to its header is forced through a call. header itself is on
+// every such path -- control returns to it at the end of every
+// iteration, including the degenerate single-block self-loop -- so a
+// call-flagged header forces every path through a call regardless of
+// what the rest of the loop looks like.
+func canReachHeaderAvoidingCalls(header *Block, in map[*Block]bool, isCall func(*Block) bool) bool {
+	if isCall(header) {
+		return false
+	}
+	seen := map[*Block]bool{}
+	var visit func(b *Block) bool
+	visit = func(b *Block) bool {
+		for _, out := range b.Out {
+			if out == header {
+				return true
+			}
+			if !in[out] || seen[out] || isCall(out) {
+				continue
+			}
+			seen[out] = true
+			if visit(out) {
+				return true
+			}
+		}
+		return false
+	}
+	seen[header] = true
+	return visit(header)
+}
+
 func (g *LoopGraph) Dump(w io.Writer) {
 	g.dump(w, &g.Root, 0)
 }
@@ -346,7 +449,7 @@ func (f *LoopFinder) FindLoops(g *CFG, lsg *LoopGraph) {
 				w.Type = bbSelf
 				continue
 			}
-			pool = append(pool, pred.Find())
+			pool = appendUnique(pool, pred.Find())
 		}
 
 		// Process node pool in order as work list.
@@ -396,6 +499,7 @@ func (f *LoopFinder) FindLoops(g *CFG, lsg *LoopGraph) {
 				// Nested loops are not added, but linked together.
 				if node.Loop != nil {
 					node.Loop.Parent = l
+					l.Child = append(l.Child, node.Loop)
 				} else {
 					l.Block = append(l.Block, node.Block)
 				}
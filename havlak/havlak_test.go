@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+var graphSizes = []struct {
+	name              string
+	outer, mid, inner int
+}{
+	{"Small", 2, 5, 3},
+	{"Medium", 5, 20, 10},
+	{"Large", 10, 100, 25}, // matches buildGraph()
+}
+
+func BenchmarkFindLoops(b *testing.B) {
+	for _, sz := range graphSizes {
+		sz := sz
+		b.Run(sz.name, func(b *testing.B) {
+			g := buildGraphN(sz.outer, sz.mid, sz.inner)
+			for _, reuse := range []bool{true, false} {
+				reuse := reuse
+				name := "NoReuse"
+				if reuse {
+					name = "Reuse"
+				}
+				b.Run(name, func(b *testing.B) {
+					b.ReportAllocs()
+
+					var f LoopFinder
+					lsg := new(LoopGraph)
+					loops := 0
+					for i := 0; i < b.N; i++ {
+						if reuse {
+							lsg.Clear()
+							f.FindLoops(g, lsg)
+						} else {
+							lsg = new(LoopGraph)
+							f.FindLoops(g, lsg)
+						}
+						loops = len(lsg.Loop)
+					}
+					b.ReportMetric(float64(loops), "loops-found")
+					if elapsed := b.Elapsed().Seconds(); elapsed > 0 {
+						b.ReportMetric(float64(len(g.Block))*float64(b.N)/elapsed, "blocks/sec")
+					}
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkBuildGraph(b *testing.B) {
+	for _, sz := range graphSizes {
+		sz := sz
+		b.Run(sz.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buildGraphN(sz.outer, sz.mid, sz.inner)
+			}
+		})
+	}
+}
+
+func BenchmarkCalculateNesting(b *testing.B) {
+	g := buildGraph()
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lsg.CalculateNesting()
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import "sync"
+
+// FindLoopsParallel partitions g into independent subgraphs rooted at
+// each child of its fan-out point (buildGraph()'s 10 outer siblings
+// off of n2 are the motivating case: apart from the exit block n1,
+// which has no successors and so lies on no loop, they share no
+// blocks, so their loops can be found concurrently) and runs an independent LoopFinder
+// over each partition in its own goroutine, using up to workers of
+// them at a time. The per-partition results are merged into lsg under
+// a mutex-protected NewLoop, with each partition's top-level loops
+// re-parented under lsg's root.
+//
+// The existing Havlak algorithm (LoopFinder.FindLoops) is strictly
+// sequential: LoopBlock.Union is shared mutable state across the
+// entire graph. FindLoopsParallel works around that by giving each
+// partition its own LoopBlock/Pool arena (a private LoopFinder) and a
+// component-local block renumbering, so no goroutine touches another's
+// union-find state.
+func (f *LoopFinder) FindLoopsParallel(g *CFG, lsg *LoopGraph, workers int) {
+	if len(g.Block) == 0 {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	parts := partitionFromEntry(g)
+	if len(parts) == 0 {
+		f.FindLoops(g, lsg)
+		return
+	}
+
+	var mu sync.Mutex
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for _, part := range parts {
+		part := part
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sub := part.cfg
+			var subFinder LoopFinder
+			subLsg := new(LoopGraph)
+			subFinder.FindLoops(sub, subLsg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			mergeLoops(lsg, subLsg, part.blocks)
+		}()
+	}
+	wg.Wait()
+}
+
+// partition is one weakly connected piece of a CFG being processed
+// independently: cfg is a renumbered copy containing just that
+// piece's blocks, and blocks maps cfg's block indices back to the
+// original *Block.
+type partition struct {
+	cfg    *CFG
+	blocks []*Block
+}
+
+// partitionFromEntry splits g into one partition per child of the
+// fan-out root (g.Block[0], or the first block downstream of it with
+// more than one successor, since a single-successor trunk like
+// buildGraph()'s n0->n2 is not where the graph actually branches)
+// that does not share any block with another child's reachable set.
+// Blocks with no successors are the exception: they cannot lie on a
+// loop, so each partition that reaches one gets its own copy. If any
+// two children share any other block, partitioning gives up and
+// returns nil so the caller falls back to a single sequential run.
+func partitionFromEntry(g *CFG) []partition {
+	root := g.Block[0]
+	seen := map[*Block]bool{root: true}
+	for len(root.Out) == 1 && !seen[root.Out[0]] {
+		root = root.Out[0]
+		seen[root] = true
+	}
+
+	owner := make([]int, len(g.Block))
+	for i := range owner {
+		owner[i] = -1
+	}
+
+	var parts []partition
+	for _, child := range root.Out {
+		if owner[child.Name] != -1 {
+			return nil // already claimed by an earlier sibling; not disjoint
+		}
+		id := len(parts)
+		blocks := reachableFrom(child, owner, id)
+		if blocks == nil {
+			return nil // overlap detected with a prior partition
+		}
+		parts = append(parts, partition{blocks: blocks})
+	}
+
+	for id := range parts {
+		parts[id].cfg = subgraph(g, parts[id].blocks)
+	}
+	return parts
+}
+
+// reachableFrom does a BFS from start, claiming each newly-seen block
+// for partition id in owner. A block with no successors that is
+// already claimed by a different partition is added to this one as
+// well, since it cannot lie on a loop. It returns nil if it encounters
+// any other block already claimed by a different partition, since that
+// means the graph is not actually split along these lines.
+func reachableFrom(start *Block, owner []int, id int) []*Block {
+	var blocks []*Block
+	sinks := map[*Block]bool{}
+	work := []*Block{start}
+	owner[start.Name] = id
+	blocks = append(blocks, start)
+	for len(work) > 0 {
+		b := work[len(work)-1]
+		work = work[:len(work)-1]
+		for _, out := range b.Out {
+			switch owner[out.Name] {
+			case id:
+				continue
+			case -1:
+				owner[out.Name] = id
+				blocks = append(blocks, out)
+				work = append(work, out)
+			default:
+				if len(out.Out) == 0 {
+					if !sinks[out] {
+						sinks[out] = true
+						blocks = append(blocks, out)
+					}
+					continue
+				}
+				return nil
+			}
+		}
+	}
+	return blocks
+}
+
+// subgraph builds a standalone CFG containing copies of blocks (with a
+// fresh 0-based numbering) and the edges between them.
+func subgraph(g *CFG, blocks []*Block) *CFG {
+	sub := new(CFG)
+	index := make(map[*Block]int, len(blocks))
+	for i, b := range blocks {
+		index[b] = i
+		sub.NewBlock()
+	}
+	for _, b := range blocks {
+		for _, out := range b.Out {
+			if j, ok := index[out]; ok {
+				sub.Connect(sub.Block[index[b]], sub.Block[j])
+			}
+		}
+	}
+	return sub
+}
+
+// mergeLoops copies every loop found in subLsg into lsg, translating
+// subLsg's component-local blocks back to the original blocks via
+// origBlocks, and re-parenting each top-level loop (Parent == nil)
+// under lsg's root.
+func mergeLoops(lsg, subLsg *LoopGraph, origBlocks []*Block) {
+	translated := make(map[*Loop]*Loop, len(subLsg.Loop))
+	for _, l := range subLsg.Loop {
+		if l.IsRoot {
+			continue
+		}
+		nl := lsg.NewLoop(len(l.Block))
+		nl.IsReducible = l.IsReducible
+		nl.Nesting = l.Nesting
+		nl.Depth = l.Depth
+		for _, b := range l.Block {
+			nl.Block = append(nl.Block, origBlocks[b.Name])
+		}
+		if l.Head != nil {
+			nl.Head = origBlocks[l.Head.Name]
+		}
+		translated[l] = nl
+	}
+	for _, l := range subLsg.Loop {
+		if l.IsRoot {
+			continue
+		}
+		nl := translated[l]
+		if l.Parent == nil || l.Parent == &subLsg.Root {
+			nl.Parent = &lsg.Root
+			lsg.Root.Child = append(lsg.Root.Child, nl)
+			continue
+		}
+		nl.Parent = translated[l.Parent]
+		nl.Parent.Child = append(nl.Parent.Child, nl)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+// InsertReschedChecks splits every reducible loop back-edge u->header
+// described by lsg into a diamond: u now points to a new "chk" block,
+// which has a fast edge back to header and a slow edge to a new
+// "reschedule" block that in turn joins header. This models the
+// structural part of the Go compiler's loop-rescheduling-check pass
+// (cmd/compile/internal/ssa/loopreschedchecks.go), minus anything to
+// do with values or phis, which this module has no concept of.
+//
+// It returns the edges it split, in u->header form, so callers can
+// attach their own metadata (e.g. which chk/reschedule blocks replaced
+// which edge) without InsertReschedChecks having to know about it.
+//
+// Calling InsertReschedChecks again on the result is a no-op: back
+// edges whose source is already a generated chk block are left alone.
+func (g *CFG) InsertReschedChecks(lsg *LoopGraph) []Edge {
+	var split []Edge
+
+	for _, l := range lsg.Loop {
+		if l.IsRoot || !l.IsReducible || l.Head == nil {
+			continue
+		}
+		header := l.Head
+		for _, u := range allBlocks(l) {
+			if u.Synthetic {
+				continue
+			}
+			if !hasEdge(u, header) {
+				continue
+			}
+			split = append(split, Edge{u.Name, header.Name})
+
+			chk := g.NewBlock()
+			chk.Synthetic = true
+			reschedule := g.NewBlock()
+			reschedule.Synthetic = true
+
+			g.redirect(u, header, chk)
+			g.Connect(chk, header) // fast path
+			g.Connect(chk, reschedule)
+			g.Connect(reschedule, header)
+		}
+	}
+
+	return split
+}
+
+func hasEdge(from, to *Block) bool {
+	for _, out := range from.Out {
+		if out == to {
+			return true
+		}
+	}
+	return false
+}
+
+// redirect rewrites the existing src->dst edge (and g.Edge) to instead
+// run src->via.
+func (g *CFG) redirect(src, dst, via *Block) {
+	for i, out := range src.Out {
+		if out == dst {
+			src.Out[i] = via
+			break
+		}
+	}
+	for i, in := range dst.In {
+		if in == src {
+			dst.In = append(dst.In[:i], dst.In[i+1:]...)
+			break
+		}
+	}
+	via.In = append(via.In, src)
+
+	for i, e := range g.Edge {
+		if e.Src == src.Name && e.Dst == dst.Name {
+			g.Edge[i].Dst = via.Name
+			break
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestInsertReschedChecks(t *testing.T) {
+	g := new(CFG)
+	entry := g.NewBlock()
+	header := g.NewBlock()
+	g.Connect(entry, header)
+	body := g.NewBlock()
+	g.Connect(header, body)
+	g.Connect(body, header) // back edge
+	exit := g.NewBlock()
+	g.Connect(header, exit)
+
+	var f LoopFinder
+	lsg := new(LoopGraph)
+	f.FindLoops(g, lsg)
+
+	before := loopHeaders(lsg)
+	if len(before) != 1 {
+		t.Fatalf("want 1 loop header before transform, got %d", len(before))
+	}
+
+	split := g.InsertReschedChecks(lsg)
+	if len(split) != 1 {
+		t.Fatalf("want 1 split back-edge, got %d", len(split))
+	}
+
+	for _, e := range split {
+		u := g.Block[e.Src]
+		var chks []*Block
+		for _, out := range u.Out {
+			if out.Synthetic {
+				chks = append(chks, out)
+			}
+		}
+		if len(chks) != 1 {
+			t.Fatalf("block %v: want exactly 1 chk successor, got %d", u, len(chks))
+		}
+	}
+
+	lsg2 := new(LoopGraph)
+	f.FindLoops(g, lsg2)
+	after := loopHeaders(lsg2)
+	if len(after) != len(before) {
+		t.Fatalf("loop header count changed after InsertReschedChecks: %d -> %d", len(before), len(after))
+	}
+	for name := range before {
+		if !after[name] {
+			t.Fatalf("block b%d was a loop header before the transform but not after", name)
+		}
+	}
+
+	// A second pass should be a no-op: no new back-edges to split.
+	if split2 := g.InsertReschedChecks(lsg2); len(split2) != 0 {
+		t.Fatalf("want 0 split back-edges on re-run, got %d", len(split2))
+	}
+}
+
+func loopHeaders(lsg *LoopGraph) map[int]bool {
+	headers := map[int]bool{}
+	for _, l := range lsg.Loop {
+		if !l.IsRoot && l.Head != nil {
+			headers[l.Head.Name] = true
+		}
+	}
+	return headers
+}